@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AuditRecord reconstructs why a particular voucher combination won for a
+// given request: which candidates were in play, which ones were chosen,
+// and how long evaluation took.
+type AuditRecord struct {
+	OrderID             string
+	ChosenVoucherCodes  []string
+	Discount            float64
+	EvaluatedCandidates int
+	Elapsed             time.Duration
+	EvaluatedAt         time.Time
+}
+
+// AuditWriter batches AuditRecords in memory and flushes them to the
+// voucher_audit table on a timer, so a burst of requests doesn't turn into
+// a burst of individual inserts.
+type AuditWriter struct {
+	db            *sql.DB
+	logger        *zap.Logger
+	flushInterval time.Duration
+	batchSize     int
+	records       chan AuditRecord
+}
+
+// NewAuditWriter returns an AuditWriter that flushes whenever batchSize
+// records have queued up or flushInterval has elapsed, whichever comes
+// first.
+func NewAuditWriter(db *sql.DB, flushInterval time.Duration, batchSize int, logger *zap.Logger) *AuditWriter {
+	return &AuditWriter{
+		db:            db,
+		logger:        logger,
+		flushInterval: flushInterval,
+		batchSize:     batchSize,
+		records:       make(chan AuditRecord, batchSize*4),
+	}
+}
+
+// Record enqueues r for the next flush. It never blocks the evaluation
+// path on a database round-trip; if the internal queue is full the record
+// is dropped and logged, since audit data is best-effort.
+func (w *AuditWriter) Record(r AuditRecord) {
+	select {
+	case w.records <- r:
+	default:
+		w.logger.Warn("audit queue full, dropping record", zap.String("order_id", r.OrderID))
+	}
+}
+
+// Run flushes queued records every flushInterval, or as soon as batchSize
+// records have queued up, until ctx is cancelled.
+func (w *AuditWriter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]AuditRecord, 0, w.batchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			w.flush(context.Background(), batch)
+			return ctx.Err()
+
+		case r := <-w.records:
+			batch = append(batch, r)
+			if len(batch) >= w.batchSize {
+				w.flush(ctx, batch)
+				batch = batch[:0]
+			}
+
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush writes batch to voucher_audit in one multi-row insert.
+func (w *AuditWriter) flush(ctx context.Context, batch []AuditRecord) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var (
+		placeholders []string
+		args         []any
+	)
+	for i, r := range batch {
+		base := i * 6
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", base+1, base+2, base+3, base+4, base+5, base+6))
+		args = append(args, r.OrderID, strings.Join(r.ChosenVoucherCodes, ","), r.Discount, r.EvaluatedCandidates, r.Elapsed.Seconds(), r.EvaluatedAt)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO voucher_audit (order_id, chosen_voucher_codes, discount, evaluated_candidates, elapsed_seconds, evaluated_at)
+		VALUES %s
+	`, strings.Join(placeholders, ", "))
+
+	if _, err := w.db.ExecContext(ctx, query, args...); err != nil {
+		w.logger.Warn("failed to flush voucher audit batch", zap.Int("records", len(batch)), zap.Error(err))
+	}
+}
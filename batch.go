@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// OrderID identifies one order in a batch evaluation request.
+type OrderID string
+
+// Order pairs an OrderID with the Cart to evaluate, for batch pricing
+// jobs that process many orders against a shared voucher set.
+type Order struct {
+	ID   OrderID
+	Cart Cart
+}
+
+// BatchResult is one order's outcome from findBestVouchersBatch.
+type BatchResult struct {
+	Discounts []Discount
+	Total     float64
+	Err       error
+}
+
+// amountCacheKey caches a payout whose discount depends only on the order
+// amount bucket, not on cart composition, so it can be reused across every
+// order that bucket's amount.
+type amountCacheKey struct {
+	voucherID int64
+	bucket    int64
+}
+
+// amountBucketSize buckets order amounts down to a multiple of this size
+// before keying the per-voucher cache, trading a little precision for a
+// much higher cache hit rate across a batch of orders.
+const amountBucketSize = 10.0
+
+// findBestVouchersBatch evaluates many orders concurrently against the
+// shared voucher set, using a single worker pool sized by GOMAXPROCS
+// rather than spinning up a pool per order. Vouchers are bucketed by
+// MinOrderAmount so each order only pays for candidates whose minimum it
+// actually clears, and amount-only payouts are cached per
+// (voucher, order-amount-bucket) so repeated amounts across the batch
+// don't re-run the same arithmetic.
+func findBestVouchersBatch(ctx context.Context, orders []Order, vouchers []Voucher, engine *RuleEngine) map[OrderID]BatchResult {
+	sortedByMin := make([]Voucher, len(vouchers))
+	copy(sortedByMin, vouchers)
+	sort.Slice(sortedByMin, func(i, j int) bool { return sortedByMin[i].MinOrderAmount < sortedByMin[j].MinOrderAmount })
+
+	var (
+		cacheMu sync.Mutex
+		cache   = make(map[amountCacheKey]Discount)
+	)
+
+	results := make(map[OrderID]BatchResult, len(orders))
+	var resultsMu sync.Mutex
+
+	workerPool := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+
+	for _, order := range orders {
+		wg.Add(1)
+		workerPool <- struct{}{}
+
+		go func(order Order) {
+			defer wg.Done()
+			defer func() { <-workerPool }()
+
+			discounts := evaluateOrder(ctx, order, sortedByMin, engine, &cacheMu, cache)
+			chosen, total := findOptimalCombination(ctx, discounts, order.Cart.Total())
+
+			resultsMu.Lock()
+			results[order.ID] = BatchResult{Discounts: chosen, Total: total}
+			resultsMu.Unlock()
+		}(order)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// eligibleVouchers returns the slice of sortedByMin (sorted ascending by
+// MinOrderAmount) whose minimum the order amount clears.
+func eligibleVouchers(sortedByMin []Voucher, orderAmount float64) []Voucher {
+	cut := sort.Search(len(sortedByMin), func(i int) bool { return sortedByMin[i].MinOrderAmount > orderAmount })
+	return sortedByMin[:cut]
+}
+
+// evaluateOrder evaluates one order's eligible vouchers, reusing cache for
+// the Apply arithmetic of payouts that depend only on the order amount
+// bucket. Applies is always run fresh, since a rule's conditions can still
+// depend on the rest of the cart even when its payout doesn't.
+func evaluateOrder(ctx context.Context, order Order, sortedByMin []Voucher, engine *RuleEngine, cacheMu *sync.Mutex, cache map[amountCacheKey]Discount) []Discount {
+	bucket := int64(order.Cart.Total() / amountBucketSize)
+
+	var discounts []Discount
+	for _, v := range eligibleVouchers(sortedByMin, order.Cart.Total()) {
+		rule, ok := engine.rules[v.Code]
+		if !ok {
+			continue
+		}
+
+		if !rule.Applies(ctx, order.Cart) {
+			continue
+		}
+
+		// amountOnly describes the payout, not the rule's conditions, so it
+		// only lets us cache the Apply arithmetic — Applies above must still
+		// run on every order, cache hit or not, otherwise a voucher whose
+		// conditions (e.g. first-order, category) fail for this order would
+		// wrongly get another order's cached discount.
+		amountOnly := false
+		if vr, ok := rule.(voucherRule); ok {
+			if p, ok := vr.payout.(interface{ amountOnly() bool }); ok {
+				amountOnly = p.amountOnly()
+			}
+		}
+
+		if amountOnly {
+			key := amountCacheKey{voucherID: v.Id, bucket: bucket}
+			cacheMu.Lock()
+			cached, hit := cache[key]
+			cacheMu.Unlock()
+			if hit {
+				discounts = append(discounts, cached)
+				continue
+			}
+		}
+
+		discount := rule.Apply(ctx, order.Cart)
+		discounts = append(discounts, discount)
+
+		if amountOnly {
+			key := amountCacheKey{voucherID: v.Id, bucket: bucket}
+			cacheMu.Lock()
+			cache[key] = discount
+			cacheMu.Unlock()
+		}
+	}
+
+	return discounts
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// TestEvaluateOrder_PercentagePayoutNotBucketed guards against caching a
+// tiered percentage discount by amount bucket: two orders whose totals fall
+// in the same bucket but floor to different discounts must each get their
+// own discount, not one order's cached value.
+func TestEvaluateOrder_PercentagePayoutNotBucketed(t *testing.T) {
+	voucher := Voucher{Id: 1, Code: "HALF50"}
+	rule := voucherRule{
+		voucherID:   voucher.Id,
+		voucherCode: voucher.Code,
+		payout: tieredPercentagePayout{brackets: []percentageBracket{
+			{MinTotal: 0, Percentage: 50},
+		}},
+	}
+	engine := NewRuleEngine(map[string]Rule{voucher.Code: rule})
+	sortedByMin := []Voucher{voucher}
+
+	orders := []Order{
+		{ID: "a", Cart: Cart{Amount: 1000}},
+		{ID: "b", Cart: Cart{Amount: 1009}},
+	}
+	bucketOf := func(o Order) int64 { return int64(o.Cart.Total() / amountBucketSize) }
+	if bucketOf(orders[0]) != bucketOf(orders[1]) {
+		t.Fatalf("test setup invalid: orders must share an amount bucket")
+	}
+
+	cacheMu := &sync.Mutex{}
+	cache := make(map[amountCacheKey]Discount)
+
+	discountsA := evaluateOrder(context.Background(), orders[0], sortedByMin, engine, cacheMu, cache)
+	discountsB := evaluateOrder(context.Background(), orders[1], sortedByMin, engine, cacheMu, cache)
+
+	if len(discountsA) != 1 || len(discountsB) != 1 {
+		t.Fatalf("expected one discount per order, got %d and %d", len(discountsA), len(discountsB))
+	}
+	if got, want := discountsA[0].Amount, 500.0; got != want {
+		t.Errorf("order a discount = %v, want %v", got, want)
+	}
+	if got, want := discountsB[0].Amount, 504.0; got != want {
+		t.Errorf("order b discount = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "time"
+
+// LineItem is a single item in a Cart, carried so that Rule implementations
+// can inspect item-level data (category, SKU, quantity) rather than only
+// the order total.
+type LineItem struct {
+	SKU      string
+	Category string
+	Price    float64
+	Quantity int
+}
+
+// Cart is the order a voucher is being evaluated against.
+type Cart struct {
+	OrderID      string
+	UserID       int64
+	UserSegment  string
+	Amount       float64
+	Items        []LineItem
+	IsFirstOrder bool
+	PlacedAt     time.Time
+}
+
+// Total returns the sum of the cart's line items. It falls back to Amount
+// when Items is empty, since callers that only have an order total (e.g.
+// legacy fetchVouchers-driven code paths) still need a working cart.
+func (c Cart) Total() float64 {
+	if len(c.Items) == 0 {
+		return c.Amount
+	}
+
+	var total float64
+	for _, item := range c.Items {
+		total += item.Price * float64(item.Quantity)
+	}
+	return total
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// greedyThreshold is the candidate-count above which findOptimalCombination
+// falls back to a greedy pick instead of exhaustive branch-and-bound, since
+// the search tree otherwise grows too large to explore in request time.
+const greedyThreshold = 24
+
+// comboResult is the best combination found so far.
+type comboResult struct {
+	discounts []Discount
+	total     float64
+}
+
+// bestResult guards the incumbent comboResult with a mutex so the two
+// top-level branches, dispatched concurrently, can't lose an update to one
+// another by both reading the same stale incumbent before either stores.
+type bestResult struct {
+	mu     sync.Mutex
+	result comboResult
+}
+
+func (b *bestResult) load() comboResult {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.result
+}
+
+// tryStore replaces the incumbent with candidate if candidate is strictly
+// better, reporting whether it did so.
+func (b *bestResult) tryStore(candidate comboResult) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if candidate.total > b.result.total {
+		b.result = candidate
+		return true
+	}
+	return false
+}
+
+// findOptimalCombination solves the multi-voucher selection problem: given
+// candidates (already filtered to those whose conditions hold for the
+// cart), find the subset maximizing total discount subject to orderCap and
+// the mutual exclusion implied by Discount.ExclusivityGroup and
+// Discount.Exclusive. Non-stackable ("exclusive") discounts without an
+// ExclusivityGroup all share one group, so at most one of them survives in
+// the result.
+//
+// The search is branch-and-bound: candidates are sorted by their own
+// amount (an admissible upper bound, since a voucher cannot discount more
+// than itself), and a branch is pruned once its current total plus the sum
+// of all remaining candidates cannot beat the best found so far. Top-level
+// branches are dispatched to a bounded worker pool; once a combination
+// that takes every candidate is stored (provably optimal), remaining
+// branches are cancelled via ctx.
+func findOptimalCombination(ctx context.Context, candidates []Discount, orderCap float64) ([]Discount, float64) {
+	if len(candidates) == 0 {
+		return nil, 0
+	}
+	if len(candidates) > greedyThreshold {
+		return greedyCombination(candidates, orderCap)
+	}
+
+	sorted := make([]Discount, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Amount > sorted[j].Amount })
+
+	suffixUpperBound := make([]float64, len(sorted)+1)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		suffixUpperBound[i] = suffixUpperBound[i+1] + sorted[i].Amount
+	}
+
+	branchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var best bestResult
+
+	var wg sync.WaitGroup
+	workerPool := make(chan struct{}, 10)
+
+	// branch recurses sequentially once past the top level; the take/skip
+	// decision for each top-level candidate is dispatched to the worker
+	// pool so the search actually runs in parallel.
+	var branch func(idx int, chosen []Discount, total float64, usedGroups map[string]bool)
+	branch = func(idx int, chosen []Discount, total float64, usedGroups map[string]bool) {
+		select {
+		case <-branchCtx.Done():
+			return
+		default:
+		}
+
+		current := best.load()
+		if total+suffixUpperBound[idx] <= current.total {
+			return // can't possibly beat the incumbent from here
+		}
+
+		if idx == len(sorted) {
+			snapshot := make([]Discount, len(chosen))
+			copy(snapshot, chosen)
+			if best.tryStore(comboResult{discounts: snapshot, total: total}) && total == suffixUpperBound[0] {
+				cancel() // took every candidate: provably optimal
+			}
+			return
+		}
+
+		d := sorted[idx]
+		group := exclusivityKey(d)
+
+		// Branch: skip this candidate.
+		runBranch := func() { branch(idx+1, chosen, total, usedGroups) }
+
+		// Branch: take this candidate, if it doesn't blow the cap or
+		// collide with an already-used exclusivity group.
+		var takeBranch func()
+		if !usedGroups[group] && total+d.Amount <= orderCap {
+			nextUsed := usedGroups
+			if group != "" {
+				nextUsed = make(map[string]bool, len(usedGroups)+1)
+				for k, v := range usedGroups {
+					nextUsed[k] = v
+				}
+				nextUsed[group] = true
+			}
+			nextChosen := append(append([]Discount{}, chosen...), d)
+			takeBranch = func() { branch(idx+1, nextChosen, total+d.Amount, nextUsed) }
+		}
+
+		if idx == 0 {
+			// Dispatch the two top-level branches to the worker pool so
+			// the search actually runs in parallel.
+			for _, fn := range []func(){runBranch, takeBranch} {
+				if fn == nil {
+					continue
+				}
+				wg.Add(1)
+				workerPool <- struct{}{}
+				go func(fn func()) {
+					defer wg.Done()
+					defer func() { <-workerPool }()
+					fn()
+				}(fn)
+			}
+			return
+		}
+
+		runBranch()
+		if takeBranch != nil {
+			takeBranch()
+		}
+	}
+
+	wg.Add(1)
+	workerPool <- struct{}{}
+	go func() {
+		defer wg.Done()
+		defer func() { <-workerPool }()
+		branch(0, nil, 0, nil)
+	}()
+	wg.Wait()
+
+	result := best.load()
+	return result.discounts, result.total
+}
+
+// exclusivityKey returns the key used to enforce mutual exclusion for d: its
+// ExclusivityGroup if set, otherwise the shared "__exclusive__" key when the
+// voucher is marked Exclusive or is not Stackable, so it can never combine
+// with another voucher under that key, including a second copy of itself.
+// Stackable, non-exclusive vouchers get the empty key and are not excluded
+// by it.
+func exclusivityKey(d Discount) string {
+	if d.ExclusivityGroup != "" {
+		return d.ExclusivityGroup
+	}
+	if d.Exclusive || !d.Stackable {
+		return "__exclusive__"
+	}
+	return ""
+}
+
+// greedyCombination is the fallback used when there are too many candidates
+// to branch-and-bound: take candidates highest-amount first, skipping any
+// that would exceed orderCap or collide with an exclusivity group already
+// taken.
+func greedyCombination(candidates []Discount, orderCap float64) ([]Discount, float64) {
+	sorted := make([]Discount, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Amount > sorted[j].Amount })
+
+	usedGroups := make(map[string]bool)
+	var chosen []Discount
+	var total float64
+
+	for _, d := range sorted {
+		group := exclusivityKey(d)
+		if usedGroups[group] {
+			continue
+		}
+		if total+d.Amount > orderCap {
+			continue
+		}
+		chosen = append(chosen, d)
+		total += d.Amount
+		if group != "" {
+			usedGroups[group] = true
+		}
+	}
+
+	return chosen, total
+}
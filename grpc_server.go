@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	pb "goroutine_channel_example/proto/voucherpb"
+)
+
+// grpcServer implements pb.VoucherServiceServer, generated from
+// proto/voucher.proto, on top of the same Server used by the HTTP handlers.
+type grpcServer struct {
+	pb.UnimplementedVoucherServiceServer
+	server *Server
+}
+
+// EvaluateVouchers reads evaluate requests off the stream and, for each
+// one, streams back partial best-combination updates as evaluateStreaming's
+// workers finish, followed by a final result.
+func (g *grpcServer) EvaluateVouchers(stream pb.VoucherService_EvaluateVouchersServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to receive evaluate request: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(stream.Context(), g.server.requestTimeout)
+		updates := g.server.evaluateStreaming(ctx, requestToCart(req), req.CandidateCodes)
+
+		for update := range updates {
+			if err := stream.Send(cartResultToProto(update)); err != nil {
+				cancel()
+				return fmt.Errorf("failed to send evaluate result: %w", err)
+			}
+		}
+		cancel()
+	}
+}
+
+// Health reports whether the server is ready to accept EvaluateVouchers
+// calls, i.e. whether its worker pool has been started and not drained.
+func (g *grpcServer) Health(ctx context.Context, _ *pb.HealthRequest) (*pb.HealthResponse, error) {
+	if g.server.draining.Load() {
+		return nil, status.Error(codes.Unavailable, "server is draining")
+	}
+	return &pb.HealthResponse{Serving: true}, nil
+}
+
+func requestToCart(req *pb.EvaluateRequest) Cart {
+	items := make([]LineItem, len(req.CartItems))
+	for i, it := range req.CartItems {
+		items[i] = LineItem{SKU: it.Sku, Category: it.Category, Price: it.Price, Quantity: int(it.Quantity)}
+	}
+	return Cart{UserID: req.UserId, Amount: req.OrderAmount, Items: items}
+}
+
+func cartResultToProto(r evaluationUpdate) *pb.EvaluateResult {
+	codes := make([]string, len(r.Discounts))
+	for i, d := range r.Discounts {
+		codes[i] = d.VoucherCode
+	}
+	return &pb.EvaluateResult{VoucherCodes: codes, Discount: r.Total, Final: r.Final}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// httpLineItem and httpEvaluateRequest mirror the gRPC EvaluateRequest
+// message so HTTP/JSON callers get the same request shape.
+type httpLineItem struct {
+	SKU      string  `json:"sku"`
+	Category string  `json:"category"`
+	Price    float64 `json:"price"`
+	Quantity int     `json:"quantity"`
+}
+
+type httpEvaluateRequest struct {
+	OrderAmount    float64        `json:"order_amount"`
+	UserID         int64          `json:"user_id"`
+	CartItems      []httpLineItem `json:"cart_items"`
+	CandidateCodes []string       `json:"candidate_codes,omitempty"`
+}
+
+type httpEvaluateResult struct {
+	VoucherCodes []string `json:"voucher_codes"`
+	Discount     float64  `json:"discount"`
+	Final        bool     `json:"final"`
+}
+
+// newHTTPMux builds the HTTP/JSON counterpart of the gRPC service: POST
+// /evaluate streams one JSON object per line as partial results arrive,
+// and GET /healthz reports whether the server still accepts work.
+func newHTTPMux(server *Server) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/evaluate", server.handleEvaluate)
+	mux.HandleFunc("/healthz", server.handleHealthz)
+	return mux
+}
+
+func (s *Server) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req httpEvaluateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.requestTimeout)
+	defer cancel()
+
+	items := make([]LineItem, len(req.CartItems))
+	for i, it := range req.CartItems {
+		items[i] = LineItem{SKU: it.SKU, Category: it.Category, Price: it.Price, Quantity: it.Quantity}
+	}
+	cart := Cart{UserID: req.UserID, Amount: req.OrderAmount, Items: items}
+
+	flusher, canFlush := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+
+	for update := range s.evaluateStreaming(ctx, cart, req.CandidateCodes) {
+		codes := make([]string, len(update.Discounts))
+		for i, d := range update.Discounts {
+			codes[i] = d.VoucherCode
+		}
+
+		if err := encoder.Encode(httpEvaluateResult{VoucherCodes: codes, Discount: update.Total, Final: update.Final}); err != nil {
+			s.logger.Warn("failed to encode evaluate result", zap.Error(err))
+			return
+		}
+		writer.Flush()
+		if canFlush {
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		http.Error(w, "draining", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// newHTTPServer wraps mux in an http.Server with sane timeouts for a
+// long-running service rather than a one-shot script.
+func newHTTPServer(addr string, mux http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
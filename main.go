@@ -6,12 +6,18 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"math"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
 	_ "github.com/lib/pq"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	pb "goroutine_channel_example/proto/voucherpb"
 )
 
 // Voucher represents a voucher with its conditions
@@ -22,6 +28,7 @@ type Voucher struct {
 	DiscountAmount     sql.NullFloat64
 	DiscountPercentage sql.NullInt64
 	MaxDiscountAmount  sql.NullFloat64
+	Expiration         time.Time
 }
 
 // Database connection details
@@ -46,95 +53,141 @@ func init() {
 	}
 }
 
-// fetchVouchers fetches all vouchers from the database
-func fetchVouchers(ctx context.Context, db *sql.DB) ([]Voucher, error) {
-	query := `
-		SELECT id, code, min_order_amount, discount_amount, discount_percentage, max_discount_amount
-		FROM vouchers
-	`
-	rows, err := db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch vouchers: %w", err)
+// defaultFetchBatchSize is the keyset page size used when callers don't
+// need a different tradeoff between round-trips and per-page memory.
+const defaultFetchBatchSize = 500
+
+// fetchVouchers streams vouchers from the database using keyset pagination
+// (WHERE id > lastID ORDER BY id LIMIT batchSize) instead of buffering the
+// whole catalog, so a consumer like findBestVoucherFromStream can start
+// evaluating rows before the query finishes. Both returned channels are
+// closed when the scan is done; an error, if any, is sent on errChan before
+// it is closed.
+func fetchVouchers(ctx context.Context, db *sql.DB, batchSize int) (<-chan Voucher, <-chan error) {
+	if batchSize <= 0 {
+		batchSize = defaultFetchBatchSize
 	}
-	defer rows.Close()
 
-	var vouchers []Voucher
-	for rows.Next() {
-		var v Voucher
-		if err := rows.Scan(&v.Id, &v.Code, &v.MinOrderAmount, &v.DiscountAmount, &v.DiscountPercentage, &v.MaxDiscountAmount); err != nil {
-			return nil, fmt.Errorf("failed to scan voucher: %w", err)
+	vouchersChan := make(chan Voucher)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(vouchersChan)
+		defer close(errChan)
+
+		const query = `
+			SELECT id, code, min_order_amount, discount_amount, discount_percentage, max_discount_amount, expiration
+			FROM vouchers
+			WHERE id > $1
+			ORDER BY id
+			LIMIT $2
+		`
+
+		var lastID int64
+		for {
+			rows, err := db.QueryContext(ctx, query, lastID, batchSize)
+			if err != nil {
+				errChan <- fmt.Errorf("failed to fetch vouchers page after id %d: %w", lastID, err)
+				return
+			}
+
+			rowsInPage := 0
+			for rows.Next() {
+				var v Voucher
+				if err := rows.Scan(&v.Id, &v.Code, &v.MinOrderAmount, &v.DiscountAmount, &v.DiscountPercentage, &v.MaxDiscountAmount, &v.Expiration); err != nil {
+					rows.Close()
+					errChan <- fmt.Errorf("failed to scan voucher: %w", err)
+					return
+				}
+
+				select {
+				case vouchersChan <- v:
+				case <-ctx.Done():
+					rows.Close()
+					errChan <- ctx.Err()
+					return
+				}
+
+				lastID = v.Id
+				rowsInPage++
+			}
+			err = rows.Err()
+			rows.Close()
+			if err != nil {
+				errChan <- fmt.Errorf("row iteration error: %w", err)
+				return
+			}
+
+			if rowsInPage < batchSize {
+				return
+			}
 		}
+	}()
+
+	return vouchersChan, errChan
+}
+
+// collectVouchers drains a fetchVouchers stream into a slice, for callers
+// such as RefreshService that need the full voucher set at once.
+func collectVouchers(vouchersChan <-chan Voucher, errChan <-chan error) ([]Voucher, error) {
+	var vouchers []Voucher
+	for v := range vouchersChan {
 		vouchers = append(vouchers, v)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration error: %w", err)
+	if err := <-errChan; err != nil {
+		return nil, err
 	}
-
 	return vouchers, nil
 }
 
-// calculateDiscount calculates the discount for a voucher
-func calculateDiscount(v Voucher, orderAmount float64) (float64, error) {
-	if orderAmount < v.MinOrderAmount {
-		return 0, errors.New("order amount does not meet minimum requirement")
-	}
-
-	var discount float64
-	if v.DiscountAmount.Valid {
-		// Flat discount
-		discount = v.DiscountAmount.Float64
-	} else if v.DiscountPercentage.Valid {
-		// Percentage discount
-		discount = orderAmount * float64(v.DiscountPercentage.Int64) / 100
-		if v.MaxDiscountAmount.Valid && discount > v.MaxDiscountAmount.Float64 {
-			discount = v.MaxDiscountAmount.Float64
-		}
-	} else {
-		return 0, errors.New("invalid voucher discount configuration")
+// findBestVoucher consults store for the current voucher set, skips
+// expired entries, evaluates the remainder against engine's rules in
+// parallel, and hands the applicable discounts to findOptimalCombination
+// to pick the best subset.
+func findBestVoucher(ctx context.Context, store VoucherStore, engine *RuleEngine, cart Cart) ([]Discount, float64, error) {
+	all, err := store.GetAll(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load vouchers from store: %w", err)
 	}
 
-	// Floor the discount to the nearest integer
-	discount = math.Floor(discount)
-	return discount, nil
-}
+	now := time.Now()
+	active := make([]Voucher, 0, len(all))
+	for _, v := range all {
+		if !v.Expiration.IsZero() && v.Expiration.Before(now) {
+			logger.Warn("skipping expired voucher", zap.String("code", v.Code), zap.Time("expiration", v.Expiration))
+			continue
+		}
+		active = append(active, v)
+	}
 
-// findBestVoucher finds the best voucher in parallel
-func findBestVoucher(ctx context.Context, vouchers []Voucher, orderAmount float64) (Voucher, float64, error) {
-	var (
-		wg          sync.WaitGroup
-		mu          sync.Mutex
-		bestVoucher Voucher
-		maxDiscount float64
-	)
+	var wg sync.WaitGroup
 
 	// Create a worker pool with a limit of 10 workers
 	workerPool := make(chan struct{}, 10)
 	defer close(workerPool)
 
-	resultChan := make(chan struct {
-		Voucher  Voucher
-		Discount float64
-	}, len(vouchers))
+	resultChan := make(chan Discount, len(active))
+
+	for _, v := range active {
+		rule, ok := engine.rules[v.Code]
+		if !ok {
+			continue
+		}
 
-	for _, v := range vouchers {
 		wg.Add(1)
 		workerPool <- struct{}{} // Acquire a worker slot
 
-		go func(v Voucher) {
+		go func(v Voucher, rule Rule) {
 			defer wg.Done()
 			defer func() { <-workerPool }() // Release the worker slot
 
-			discount, err := calculateDiscount(v, orderAmount)
-			if err != nil {
-				logger.Warn("Voucher condition not met", zap.String("code", v.Code), zap.Error(err))
+			if !rule.Applies(ctx, cart) {
+				logger.Warn("Voucher condition not met", zap.String("code", v.Code))
 				return
 			}
 
-			resultChan <- struct {
-				Voucher  Voucher
-				Discount float64
-			}{Voucher: v, Discount: discount}
-		}(v)
+			resultChan <- rule.Apply(ctx, cart)
+		}(v, rule)
 	}
 
 	go func() {
@@ -142,20 +195,81 @@ func findBestVoucher(ctx context.Context, vouchers []Voucher, orderAmount float6
 		close(resultChan)
 	}()
 
-	for result := range resultChan {
-		mu.Lock()
-		if result.Discount > maxDiscount {
-			maxDiscount = result.Discount
-			bestVoucher = result.Voucher
+	var discounts []Discount
+	for discount := range resultChan {
+		discounts = append(discounts, discount)
+	}
+
+	chosen, total := findOptimalCombination(ctx, discounts, cart.Total())
+	if len(chosen) == 0 {
+		return nil, 0, errors.New("no applicable voucher found")
+	}
+
+	return chosen, total, nil
+}
+
+// findBestVoucherFromStream evaluates vouchers directly off a fetchVouchers
+// stream, dispatching each arriving voucher to the worker pool as soon as
+// it's scanned rather than waiting for the whole catalog to load. This
+// keeps memory flat for large catalogs instead of buffering the full
+// result set the way findBestVoucher's store-backed GetAll does.
+func findBestVoucherFromStream(ctx context.Context, vouchersChan <-chan Voucher, errChan <-chan error, engine *RuleEngine, cart Cart) ([]Discount, float64, error) {
+	var wg sync.WaitGroup
+
+	// Create a worker pool with a limit of 10 workers
+	workerPool := make(chan struct{}, 10)
+	defer close(workerPool)
+
+	resultChan := make(chan Discount)
+	now := time.Now()
+
+	go func() {
+		defer close(resultChan)
+		defer wg.Wait()
+
+	dispatch:
+		for v := range vouchersChan {
+			if !v.Expiration.IsZero() && v.Expiration.Before(now) {
+				continue
+			}
+			rule, ok := engine.rules[v.Code]
+			if !ok {
+				continue
+			}
+
+			wg.Add(1)
+			select {
+			case workerPool <- struct{}{}:
+			case <-ctx.Done():
+				wg.Done()
+				break dispatch
+			}
+
+			go func(rule Rule) {
+				defer wg.Done()
+				defer func() { <-workerPool }()
+
+				if rule.Applies(ctx, cart) {
+					resultChan <- rule.Apply(ctx, cart)
+				}
+			}(rule)
 		}
-		mu.Unlock()
+	}()
+
+	var discounts []Discount
+	for discount := range resultChan {
+		discounts = append(discounts, discount)
+	}
+	if err := <-errChan; err != nil {
+		return nil, 0, fmt.Errorf("failed to stream vouchers: %w", err)
 	}
 
-	if maxDiscount == 0 {
-		return Voucher{}, 0, errors.New("no applicable voucher found")
+	chosen, total := findOptimalCombination(ctx, discounts, cart.Total())
+	if len(chosen) == 0 {
+		return nil, 0, errors.New("no applicable voucher found")
 	}
 
-	return bestVoucher, maxDiscount, nil
+	return chosen, total, nil
 }
 
 func main() {
@@ -170,29 +284,103 @@ func main() {
 	db.SetConnMaxLifetime(time.Minute * 5) // Close connections after 5 minutes
 	defer db.Close()
 
-	// Set up context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	defer cancel()
+	// Warm-up context, independent of the long-running server lifetime.
+	warmupCtx, cancelWarmup := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelWarmup()
+
+	// The in-memory store serves the Server's evaluations; the refresh service keeps
+	// it warm by re-fetching vouchers whose expiration is within the buffer.
+	store := NewInMemoryVoucherStore()
+	refresher := NewRefreshService(store, func(ctx context.Context) ([]Voucher, error) {
+		return collectVouchers(fetchVouchers(ctx, db, defaultFetchBatchSize))
+	}, time.Minute, 5*time.Minute, logger)
+
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	defer stopRefresh()
+	go func() {
+		if err := refresher.Run(refreshCtx); err != nil && !errors.Is(err, context.Canceled) {
+			logger.Error("voucher refresh service stopped", zap.Error(err))
+		}
+	}()
 
-	// Fetch vouchers from the database
-	vouchers, err := fetchVouchers(ctx, db)
+	// Populate the store once up front; Run's first refresh comes only after
+	// one cycle.
+	if err := refresher.refreshAll(warmupCtx); err != nil {
+		logger.Fatal("Failed to warm voucher store", zap.Error(err))
+	}
+
+	engine, err := LoadRuleEngine(warmupCtx, db)
 	if err != nil {
-		logger.Fatal("Failed to fetch vouchers", zap.Error(err))
+		logger.Fatal("Failed to load voucher rules", zap.Error(err))
 	}
 
-	// Define order amount
-	orderAmount := 500.0
+	auditWriter := NewAuditWriter(db, 5*time.Second, 100, logger)
+	auditCtx, stopAudit := context.WithCancel(context.Background())
+	defer stopAudit()
+	go func() {
+		if err := auditWriter.Run(auditCtx); err != nil && !errors.Is(err, context.Canceled) {
+			logger.Error("audit writer stopped", zap.Error(err))
+		}
+	}()
 
-	// Find the best voucher
-	bestVoucher, discount, err := findBestVoucher(ctx, vouchers, orderAmount)
+	server := NewServer(store, engine, 10, 30*time.Second, auditWriter, logger)
+	runServers(server, logger)
+}
+
+// runServers starts the gRPC, HTTP and admin listeners and blocks until an
+// interrupt or termination signal arrives, then drains the worker pool and
+// shuts all three listeners down gracefully.
+func runServers(server *Server, logger *zap.Logger) {
+	grpcServerImpl := grpc.NewServer()
+	pb.RegisterVoucherServiceServer(grpcServerImpl, &grpcServer{server: server})
+
+	grpcListener, err := net.Listen("tcp", ":9090")
 	if err != nil {
-		logger.Error("Failed to find best voucher", zap.Error(err))
-		return
+		logger.Fatal("Failed to listen for gRPC", zap.Error(err))
 	}
+	go func() {
+		if err := grpcServerImpl.Serve(grpcListener); err != nil {
+			logger.Error("gRPC server stopped", zap.Error(err))
+		}
+	}()
+
+	httpServer := newHTTPServer(":8080", newHTTPMux(server))
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("HTTP server stopped", zap.Error(err))
+		}
+	}()
+
+	adminServer := newHTTPServer(":8081", newAdminMux())
+	go func() {
+		if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("admin server stopped", zap.Error(err))
+		}
+	}()
 
-	logger.Info("Best voucher found",
-		zap.Int64("id", bestVoucher.Id),
-		zap.String("code", bestVoucher.Code),
-		zap.Float64("discount", discount),
+	logger.Info("voucher evaluation service listening",
+		zap.String("grpc", grpcListener.Addr().String()),
+		zap.String("http", httpServer.Addr),
+		zap.String("admin", adminServer.Addr),
 	)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	logger.Info("shutting down voucher evaluation service")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("voucher server did not drain cleanly", zap.Error(err))
+	}
+
+	grpcServerImpl.GracefulStop()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("HTTP server did not shut down cleanly", zap.Error(err))
+	}
+	if err := adminServer.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("admin server did not shut down cleanly", zap.Error(err))
+	}
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer emits the parent-span-per-request, child-span-per-voucher-
+// evaluation spans used throughout the evaluation path.
+var tracer = otel.Tracer("goroutine_channel_example/voucher")
+
+var (
+	vouchersEvaluatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "vouchers_evaluated_total",
+		Help: "Number of vouchers whose rule has been evaluated against a cart.",
+	})
+
+	voucherEvaluationSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "voucher_evaluation_seconds",
+		Help:    "Time to evaluate one voucher's rule against a cart.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	voucherPoolSaturation = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "voucher_pool_saturation",
+		Help: "Fraction of the evaluation worker pool currently in use, in [0, 1].",
+	})
+
+	bestDiscountAmount = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "best_discount_amount",
+		Help:    "Discount amount of the winning voucher combination per request.",
+		Buckets: []float64{5, 10, 25, 50, 100, 250, 500, 1000},
+	})
+)
+
+func init() {
+	prometheus.MustRegister(vouchersEvaluatedTotal, voucherEvaluationSeconds, voucherPoolSaturation, bestDiscountAmount)
+}
+
+// startEvaluationSpan starts the per-voucher child span used while a
+// worker runs Applies/Apply for a single voucher, tagging it with the
+// voucher code so traces can be filtered per voucher in the backend.
+func startEvaluationSpan(ctx context.Context, code string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "voucher.evaluate", trace.WithAttributes(attribute.String("voucher.code", code)))
+}
+
+// reportPoolSaturation publishes how full a worker pool of size capacity
+// is, given inUse workers currently holding a slot.
+func reportPoolSaturation(inUse, capacity int) {
+	if capacity == 0 {
+		return
+	}
+	voucherPoolSaturation.Set(float64(inUse) / float64(capacity))
+}
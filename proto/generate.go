@@ -0,0 +1,5 @@
+// Package proto holds the VoucherService definition and the recipe for
+// regenerating its Go bindings under voucherpb/.
+package proto
+
+//go:generate protoc --go_out=. --go_opt=module=goroutine_channel_example/proto --go-grpc_out=. --go-grpc_opt=module=goroutine_channel_example/proto voucher.proto
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// voucherRuleRow mirrors one row of the voucher_rules table, joined to
+// vouchers for the code, stackable and exclusive flags that payouts need.
+type voucherRuleRow struct {
+	VoucherID        int64
+	Code             string
+	Stackable        bool
+	Exclusive        bool
+	ExclusivityGroup sql.NullString
+	ConditionType    string
+	Categories       sql.NullString // comma-separated
+	SKUs             sql.NullString // comma-separated
+	Segments         sql.NullString // comma-separated
+	ValidFrom        sql.NullTime
+	ValidUntil       sql.NullTime
+	MinAmount        sql.NullFloat64
+	PayoutType       string
+	FlatAmount       sql.NullFloat64
+	Percentage       sql.NullInt64
+	MaxDiscount      sql.NullFloat64
+	BogoSKU          sql.NullString
+	BogoBuyQty       sql.NullInt64
+}
+
+// LoadRuleEngine builds a RuleEngine from the voucher_rules table, joined to
+// vouchers. Each voucher may have several rows whose conditions are ANDed
+// together. Its payout is built from its first row, except for
+// tiered_percentage, where every row adds one bracket.
+func LoadRuleEngine(ctx context.Context, db *sql.DB) (*RuleEngine, error) {
+	const query = `
+		SELECT
+			vr.voucher_id, v.code, v.stackable, v.exclusive, v.exclusivity_group,
+			vr.condition_type, vr.categories, vr.skus, vr.segments,
+			vr.valid_from, vr.valid_until, vr.min_amount,
+			vr.payout_type, vr.flat_amount, vr.percentage, vr.max_discount,
+			vr.bogo_sku, vr.bogo_buy_qty
+		FROM voucher_rules vr
+		JOIN vouchers v ON v.id = vr.voucher_id
+		ORDER BY vr.voucher_id
+	`
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load voucher rules: %w", err)
+	}
+	defer rows.Close()
+
+	conditionsByVoucher := make(map[string][]Condition)
+	payoutByVoucher := make(map[string]payout)
+	bracketsByVoucher := make(map[string][]percentageBracket)
+	flagsByVoucher := make(map[string]voucherRuleRow)
+
+	for rows.Next() {
+		var r voucherRuleRow
+		if err := rows.Scan(
+			&r.VoucherID, &r.Code, &r.Stackable, &r.Exclusive, &r.ExclusivityGroup,
+			&r.ConditionType, &r.Categories, &r.SKUs, &r.Segments,
+			&r.ValidFrom, &r.ValidUntil, &r.MinAmount,
+			&r.PayoutType, &r.FlatAmount, &r.Percentage, &r.MaxDiscount,
+			&r.BogoSKU, &r.BogoBuyQty,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan voucher rule: %w", err)
+		}
+
+		flagsByVoucher[r.Code] = r
+
+		if cond := buildCondition(r); cond != nil {
+			conditionsByVoucher[r.Code] = append(conditionsByVoucher[r.Code], cond)
+		}
+
+		// tiered_percentage vouchers spread their brackets across one row per
+		// tier, so they accumulate instead of being built once like the other
+		// payout types.
+		if r.PayoutType == "tiered_percentage" {
+			bracketsByVoucher[r.Code] = append(bracketsByVoucher[r.Code], percentageBracket{
+				MinTotal:    r.MinAmount.Float64,
+				Percentage:  r.Percentage.Int64,
+				MaxDiscount: r.MaxDiscount,
+			})
+		} else if _, ok := payoutByVoucher[r.Code]; !ok {
+			payoutByVoucher[r.Code] = buildPayout(r)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	rules := make(map[string]Rule, len(flagsByVoucher))
+	for code, flags := range flagsByVoucher {
+		p := payoutByVoucher[code]
+		if brackets := bracketsByVoucher[code]; len(brackets) > 0 {
+			p = tieredPercentagePayout{brackets: brackets}
+		}
+		rules[code] = voucherRule{
+			voucherID:        flags.VoucherID,
+			voucherCode:      code,
+			conditions:       conditionsByVoucher[code],
+			payout:           p,
+			stackable:        flags.Stackable,
+			exclusive:        flags.Exclusive,
+			exclusivityGroup: flags.ExclusivityGroup.String,
+		}
+	}
+
+	return NewRuleEngine(rules), nil
+}
+
+func buildCondition(r voucherRuleRow) Condition {
+	switch r.ConditionType {
+	case "category":
+		return categoryCondition{categories: splitSet(r.Categories.String)}
+	case "sku":
+		return skuCondition{skus: splitSet(r.SKUs.String)}
+	case "first_order":
+		return firstOrderCondition{}
+	case "user_segment":
+		return userSegmentCondition{segments: splitSet(r.Segments.String)}
+	case "time_window":
+		if r.ValidFrom.Valid && r.ValidUntil.Valid {
+			return timeWindowCondition{from: r.ValidFrom.Time, until: r.ValidUntil.Time}
+		}
+	case "min_order_amount":
+		if r.MinAmount.Valid {
+			return minOrderAmountCondition{minAmount: r.MinAmount.Float64}
+		}
+	}
+	return nil
+}
+
+// buildPayout builds the payout types backed by a single row. tiered_percentage
+// is handled separately in LoadRuleEngine, since its brackets are spread
+// across multiple rows per voucher.
+func buildPayout(r voucherRuleRow) payout {
+	switch r.PayoutType {
+	case "flat":
+		return flatPayout{amount: r.FlatAmount.Float64}
+	case "bogo":
+		return bogoPayout{sku: r.BogoSKU.String, buy: int(r.BogoBuyQty.Int64), percentage: r.Percentage.Int64}
+	default:
+		return flatPayout{amount: 0}
+	}
+}
+
+func splitSet(csv string) map[string]struct{} {
+	set := make(map[string]struct{})
+	if csv == "" {
+		return set
+	}
+
+	start := 0
+	for i := 0; i <= len(csv); i++ {
+		if i == len(csv) || csv[i] == ',' {
+			if i > start {
+				set[csv[start:i]] = struct{}{}
+			}
+			start = i + 1
+		}
+	}
+	return set
+}
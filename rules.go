@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+)
+
+// Discount is the outcome of applying a Rule to a Cart.
+type Discount struct {
+	VoucherID        int64
+	VoucherCode      string
+	Amount           float64
+	Stackable        bool
+	Exclusive        bool
+	ExclusivityGroup string // vouchers sharing a group are mutually exclusive
+}
+
+// Rule is a single voucher's eligibility and payout logic. Applies reports
+// whether the voucher is usable for cart at all; Apply computes the
+// discount assuming Applies already returned true.
+type Rule interface {
+	Applies(ctx context.Context, cart Cart) bool
+	Apply(ctx context.Context, cart Cart) Discount
+}
+
+// Condition is one eligibility check a voucherRule combines with others via
+// logical AND: category/SKU restrictions, first-order-only, user-segment,
+// and time-window validity all implement it.
+type Condition interface {
+	Matches(ctx context.Context, cart Cart) bool
+}
+
+// categoryCondition requires the cart to contain at least one item from one
+// of Categories.
+type categoryCondition struct {
+	categories map[string]struct{}
+}
+
+func (c categoryCondition) Matches(_ context.Context, cart Cart) bool {
+	for _, item := range cart.Items {
+		if _, ok := c.categories[item.Category]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// skuCondition requires the cart to contain at least one of SKUs.
+type skuCondition struct {
+	skus map[string]struct{}
+}
+
+func (c skuCondition) Matches(_ context.Context, cart Cart) bool {
+	for _, item := range cart.Items {
+		if _, ok := c.skus[item.SKU]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// firstOrderCondition requires the cart to belong to the user's first
+// order.
+type firstOrderCondition struct{}
+
+func (firstOrderCondition) Matches(_ context.Context, cart Cart) bool {
+	return cart.IsFirstOrder
+}
+
+// userSegmentCondition requires the cart's user to belong to one of
+// Segments.
+type userSegmentCondition struct {
+	segments map[string]struct{}
+}
+
+func (c userSegmentCondition) Matches(_ context.Context, cart Cart) bool {
+	_, ok := c.segments[cart.UserSegment]
+	return ok
+}
+
+// timeWindowCondition requires the cart to have been placed between From
+// and Until.
+type timeWindowCondition struct {
+	from, until time.Time
+}
+
+func (c timeWindowCondition) Matches(_ context.Context, cart Cart) bool {
+	return !cart.PlacedAt.Before(c.from) && !cart.PlacedAt.After(c.until)
+}
+
+// minOrderAmountCondition requires the cart total to meet a minimum.
+type minOrderAmountCondition struct {
+	minAmount float64
+}
+
+func (c minOrderAmountCondition) Matches(_ context.Context, cart Cart) bool {
+	return cart.Total() >= c.minAmount
+}
+
+// payout computes the Discount for a voucher once its conditions are
+// satisfied. flatPayout, tieredPercentagePayout, and bogoPayout implement
+// it.
+type payout interface {
+	discount(ctx context.Context, cart Cart) float64
+}
+
+// flatPayout knocks a flat amount off the cart total.
+type flatPayout struct {
+	amount float64
+}
+
+func (p flatPayout) discount(_ context.Context, _ Cart) float64 {
+	return p.amount
+}
+
+// amountOnly reports that a flat discount depends only on the order
+// amount (trivially: not even that), so it is safe for batch evaluation to
+// cache per (voucher, order-amount-bucket).
+func (p flatPayout) amountOnly() bool { return true }
+
+// percentageBracket is one tier of a tieredPercentagePayout: carts totaling
+// at least MinTotal get Percentage off, capped at MaxDiscount when it is
+// set.
+type percentageBracket struct {
+	MinTotal    float64
+	Percentage  int64
+	MaxDiscount sql.NullFloat64
+}
+
+// tieredPercentagePayout applies the bracket with the largest MinTotal that
+// the cart total qualifies for.
+type tieredPercentagePayout struct {
+	brackets []percentageBracket
+}
+
+func (p tieredPercentagePayout) discount(_ context.Context, cart Cart) float64 {
+	var best *percentageBracket
+	total := cart.Total()
+	for i := range p.brackets {
+		b := p.brackets[i]
+		if total >= b.MinTotal && (best == nil || b.MinTotal > best.MinTotal) {
+			best = &p.brackets[i]
+		}
+	}
+	if best == nil {
+		return 0
+	}
+
+	discount := total * float64(best.Percentage) / 100
+	if best.MaxDiscount.Valid && discount > best.MaxDiscount.Float64 {
+		discount = best.MaxDiscount.Float64
+	}
+	return discount
+}
+
+// amountOnly reports false: unlike flatPayout, the discount is
+// total*Percentage/100, which varies continuously with the cart total, so
+// bucketing it for batch evaluation's amount cache would hand one order's
+// discount to every other order in the same (coarser) bucket.
+func (p tieredPercentagePayout) amountOnly() bool { return false }
+
+// bogoPayout is a buy-one-get-one payout: for every Buy qualifying items in
+// the cart, one more is discounted by Percentage (100 for a fully free
+// item).
+type bogoPayout struct {
+	sku        string
+	buy        int
+	percentage int64
+}
+
+func (p bogoPayout) eligibleQuantity(cart Cart) (qty int, unitPrice float64) {
+	for _, item := range cart.Items {
+		if item.SKU == p.sku {
+			qty += item.Quantity
+			unitPrice = item.Price
+		}
+	}
+	return qty, unitPrice
+}
+
+func (p bogoPayout) discount(_ context.Context, cart Cart) float64 {
+	qty, unitPrice := p.eligibleQuantity(cart)
+	free := qty / (p.buy + 1)
+	return float64(free) * unitPrice * float64(p.percentage) / 100
+}
+
+// voucherRule composes a voucher's Conditions (all must match) with its
+// Payout to implement Rule.
+type voucherRule struct {
+	voucherID        int64
+	voucherCode      string
+	conditions       []Condition
+	payout           payout
+	stackable        bool
+	exclusive        bool
+	exclusivityGroup string
+}
+
+func (r voucherRule) Applies(ctx context.Context, cart Cart) bool {
+	for _, c := range r.conditions {
+		if !c.Matches(ctx, cart) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r voucherRule) Apply(ctx context.Context, cart Cart) Discount {
+	return Discount{
+		VoucherID:        r.voucherID,
+		VoucherCode:      r.voucherCode,
+		Amount:           math.Floor(r.payout.discount(ctx, cart)),
+		Stackable:        r.stackable,
+		Exclusive:        r.exclusive,
+		ExclusivityGroup: r.exclusivityGroup,
+	}
+}
+
+// RuleEngine evaluates the Rule registered for each voucher and returns the
+// Discount for every voucher whose Rule applies to cart.
+type RuleEngine struct {
+	rules map[string]Rule // voucher code -> rule
+}
+
+// NewRuleEngine builds a RuleEngine from a voucher-code-to-rule mapping, as
+// loaded by LoadRuleEngine.
+func NewRuleEngine(rules map[string]Rule) *RuleEngine {
+	return &RuleEngine{rules: rules}
+}
+
+// Evaluate returns the Discount for every voucher whose rule applies to
+// cart.
+func (e *RuleEngine) Evaluate(ctx context.Context, cart Cart) []Discount {
+	discounts := make([]Discount, 0, len(e.rules))
+	for _, rule := range e.rules {
+		if rule.Applies(ctx, cart) {
+			discounts = append(discounts, rule.Apply(ctx, cart))
+		}
+	}
+	return discounts
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// evaluationUpdate is one step of a streaming voucher evaluation: a
+// running best combination while workers are still finishing, followed by
+// one update with Final set to true.
+type evaluationUpdate struct {
+	Discounts []Discount
+	Total     float64
+	Final     bool
+}
+
+// Server wraps findBestVoucher's store/engine/worker-pool machinery behind
+// a long-running service that the gRPC and HTTP handlers share, so other
+// systems can integrate with voucher evaluation instead of shelling out to
+// the one-shot main.
+type Server struct {
+	store          VoucherStore
+	engine         *RuleEngine
+	requestTimeout time.Duration
+	workerPool     chan struct{}
+	poolInUse      atomic.Int64
+	inFlight       sync.WaitGroup
+	draining       atomic.Bool
+	auditWriter    *AuditWriter
+	logger         *zap.Logger
+}
+
+// NewServer builds a Server backed by store and engine. poolSize bounds how
+// many voucher evaluations run concurrently across all requests, since the
+// pool is shared by every evaluateStreaming call. auditWriter may be
+// nil, in which case no audit records are written.
+func NewServer(store VoucherStore, engine *RuleEngine, poolSize int, requestTimeout time.Duration, auditWriter *AuditWriter, logger *zap.Logger) *Server {
+	return &Server{
+		store:          store,
+		engine:         engine,
+		requestTimeout: requestTimeout,
+		workerPool:     make(chan struct{}, poolSize),
+		auditWriter:    auditWriter,
+		logger:         logger,
+	}
+}
+
+// evaluateStreaming evaluates cart against the voucher catalog, emitting a
+// running best combination on the returned channel each time a voucher's
+// rule applies, then a final update once every candidate has been checked.
+// The channel is closed once the final update has been sent or ctx is done,
+// or without any update when the server is draining or the store cannot be
+// read. When
+// candidateCodes is non-empty, evaluation is restricted to those codes
+// instead of the full catalog.
+func (s *Server) evaluateStreaming(ctx context.Context, cart Cart, candidateCodes []string) <-chan evaluationUpdate {
+	out := make(chan evaluationUpdate)
+
+	if s.draining.Load() {
+		close(out)
+		return out
+	}
+
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+		defer close(out)
+
+		start := time.Now()
+		ctx, span := tracer.Start(ctx, "voucher.evaluate_request", trace.WithAttributes(
+			attribute.String("order.id", cart.OrderID),
+			attribute.Int64("user.id", cart.UserID),
+		))
+		defer span.End()
+
+		all, err := s.store.GetAll(ctx)
+		if err != nil {
+			s.logger.Warn("failed to load vouchers for streaming evaluation", zap.Error(err))
+			return
+		}
+
+		var candidateSet map[string]struct{}
+		if len(candidateCodes) > 0 {
+			candidateSet = make(map[string]struct{}, len(candidateCodes))
+			for _, code := range candidateCodes {
+				candidateSet[code] = struct{}{}
+			}
+		}
+
+		now := time.Now()
+		active := make([]Voucher, 0, len(all))
+		for _, v := range all {
+			if !v.Expiration.IsZero() && v.Expiration.Before(now) {
+				continue
+			}
+			if candidateSet != nil {
+				if _, ok := candidateSet[v.Code]; !ok {
+					continue
+				}
+			}
+			active = append(active, v)
+		}
+
+		type result struct {
+			discount Discount
+		}
+		resultChan := make(chan result, len(active))
+
+		var wg sync.WaitGroup
+		evaluated := 0
+		for _, v := range active {
+			rule, ok := s.engine.rules[v.Code]
+			if !ok {
+				continue
+			}
+
+			wg.Add(1)
+			select {
+			case s.workerPool <- struct{}{}:
+			case <-ctx.Done():
+				wg.Done()
+				continue
+			}
+			s.poolInUse.Add(1)
+			reportPoolSaturation(int(s.poolInUse.Load()), cap(s.workerPool))
+			evaluated++
+
+			go func(code string, rule Rule) {
+				defer wg.Done()
+				defer func() {
+					<-s.workerPool
+					s.poolInUse.Add(-1)
+					reportPoolSaturation(int(s.poolInUse.Load()), cap(s.workerPool))
+				}()
+
+				spanCtx, evalSpan := startEvaluationSpan(ctx, code)
+				defer evalSpan.End()
+
+				evalStart := time.Now()
+				applies := rule.Applies(spanCtx, cart)
+				voucherEvaluationSeconds.Observe(time.Since(evalStart).Seconds())
+				vouchersEvaluatedTotal.Inc()
+
+				if !applies {
+					return
+				}
+				resultChan <- result{discount: rule.Apply(spanCtx, cart)}
+			}(v.Code, rule)
+		}
+
+		go func() {
+			wg.Wait()
+			close(resultChan)
+		}()
+
+		var discounts []Discount
+		for r := range resultChan {
+			discounts = append(discounts, r.discount)
+
+			running, total := findOptimalCombination(ctx, discounts, cart.Total())
+			select {
+			case out <- evaluationUpdate{Discounts: running, Total: total}:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		final, total := findOptimalCombination(ctx, discounts, cart.Total())
+		bestDiscountAmount.Observe(total)
+
+		if s.auditWriter != nil {
+			codes := make([]string, len(final))
+			for i, d := range final {
+				codes[i] = d.VoucherCode
+			}
+			s.auditWriter.Record(AuditRecord{
+				OrderID:             cart.OrderID,
+				ChosenVoucherCodes:  codes,
+				Discount:            total,
+				EvaluatedCandidates: evaluated,
+				Elapsed:             time.Since(start),
+				EvaluatedAt:         start,
+			})
+		}
+
+		select {
+		case out <- evaluationUpdate{Discounts: final, Total: total, Final: true}:
+		case <-ctx.Done():
+		}
+	}()
+
+	return out
+}
+
+// Shutdown stops accepting new evaluations and waits for in-flight ones to
+// drain, or for ctx to expire.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.draining.Store(true)
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out draining in-flight evaluations: %w", ctx.Err())
+	}
+}
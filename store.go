@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// VoucherStore abstracts persistence and caching of vouchers so that
+// findBestVoucher does not need to know whether it is talking to Postgres,
+// an in-memory cache, or some other backing store.
+type VoucherStore interface {
+	// Put upserts a voucher into the store.
+	Put(ctx context.Context, v Voucher) error
+
+	// GetAll returns every voucher currently known to the store, including
+	// expired ones. Callers are expected to filter on Expiration themselves.
+	GetAll(ctx context.Context) ([]Voucher, error)
+
+	// NeedRefresh reports whether the voucher identified by key is missing
+	// or will expire within expirationBuffer of now, and therefore needs to
+	// be re-fetched from the system of record.
+	NeedRefresh(ctx context.Context, key string, expirationBuffer time.Duration) (bool, error)
+}
+
+// RefreshService periodically re-fetches vouchers whose expiration falls
+// within refreshBuffer of now, keeping store up to date without requiring
+// every call to findBestVoucher to hit the database.
+type RefreshService struct {
+	store         VoucherStore
+	fetch         func(ctx context.Context) ([]Voucher, error)
+	cycle         time.Duration
+	refreshBuffer time.Duration
+	logger        *zap.Logger
+}
+
+// NewRefreshService builds a RefreshService that runs fetch every cycle and
+// writes the results into store, logging through logger.
+func NewRefreshService(store VoucherStore, fetch func(ctx context.Context) ([]Voucher, error), cycle, refreshBuffer time.Duration, logger *zap.Logger) *RefreshService {
+	return &RefreshService{
+		store:         store,
+		fetch:         fetch,
+		cycle:         cycle,
+		refreshBuffer: refreshBuffer,
+		logger:        logger,
+	}
+}
+
+// Run blocks, refreshing vouchers every Cycle until ctx is cancelled.
+func (s *RefreshService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cycle)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := s.refreshAll(ctx); err != nil {
+				s.logger.Warn("voucher refresh failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// refreshAll fetches every voucher and writes back into the store those that
+// expire within refreshBuffer, have no expiration, or for which the store's
+// NeedRefresh reports true.
+func (s *RefreshService) refreshAll(ctx context.Context) error {
+	vouchers, err := s.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch vouchers for refresh: %w", err)
+	}
+
+	now := time.Now()
+	refreshed := 0
+	for _, v := range vouchers {
+		if !v.Expiration.IsZero() && v.Expiration.After(now.Add(s.refreshBuffer)) {
+			needRefresh, err := s.store.NeedRefresh(ctx, v.Code, s.refreshBuffer)
+			if err != nil {
+				return fmt.Errorf("failed to check refresh state for %s: %w", v.Code, err)
+			}
+			if !needRefresh {
+				continue
+			}
+		}
+
+		if err := s.store.Put(ctx, v); err != nil {
+			return fmt.Errorf("failed to persist voucher %s: %w", v.Code, err)
+		}
+		refreshed++
+	}
+
+	s.logger.Info("voucher store refreshed", zap.Int("refreshed", refreshed), zap.Int("total", len(vouchers)))
+	return nil
+}
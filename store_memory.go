@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// InMemoryVoucherStore is a VoucherStore backed by a guarded map. main uses
+// it as the cache that RefreshService fills from fetchVouchers.
+type InMemoryVoucherStore struct {
+	mu       sync.RWMutex
+	vouchers map[string]Voucher
+}
+
+// NewInMemoryVoucherStore returns an empty InMemoryVoucherStore.
+func NewInMemoryVoucherStore() *InMemoryVoucherStore {
+	return &InMemoryVoucherStore{
+		vouchers: make(map[string]Voucher),
+	}
+}
+
+// Put upserts a voucher, keyed by its code.
+func (s *InMemoryVoucherStore) Put(_ context.Context, v Voucher) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vouchers[v.Code] = v
+	return nil
+}
+
+// GetAll returns every voucher currently cached.
+func (s *InMemoryVoucherStore) GetAll(_ context.Context) ([]Voucher, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	vouchers := make([]Voucher, 0, len(s.vouchers))
+	for _, v := range s.vouchers {
+		vouchers = append(vouchers, v)
+	}
+	return vouchers, nil
+}
+
+// NeedRefresh reports whether the voucher identified by code is missing, or
+// expires within expirationBuffer of now.
+func (s *InMemoryVoucherStore) NeedRefresh(_ context.Context, code string, expirationBuffer time.Duration) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.vouchers[code]
+	if !ok {
+		return true, nil
+	}
+	if v.Expiration.IsZero() {
+		return false, nil
+	}
+
+	return v.Expiration.Before(time.Now().Add(expirationBuffer)), nil
+}
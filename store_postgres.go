@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// PostgresVoucherStore is a VoucherStore backed by the vouchers table. It
+// mirrors the storagenode vouchers DB pattern: writes are upserts keyed on
+// code, and NeedRefresh is answered with a single indexed lookup rather than
+// pulling the whole row set.
+type PostgresVoucherStore struct {
+	db *sql.DB
+}
+
+// NewPostgresVoucherStore wraps db as a VoucherStore.
+func NewPostgresVoucherStore(db *sql.DB) *PostgresVoucherStore {
+	return &PostgresVoucherStore{db: db}
+}
+
+// Put upserts a voucher, keyed by its code.
+func (s *PostgresVoucherStore) Put(ctx context.Context, v Voucher) error {
+	const query = `
+		INSERT INTO vouchers (id, code, min_order_amount, discount_amount, discount_percentage, max_discount_amount, expiration)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (code) DO UPDATE SET
+			min_order_amount = EXCLUDED.min_order_amount,
+			discount_amount = EXCLUDED.discount_amount,
+			discount_percentage = EXCLUDED.discount_percentage,
+			max_discount_amount = EXCLUDED.max_discount_amount,
+			expiration = EXCLUDED.expiration
+	`
+	if _, err := s.db.ExecContext(ctx, query, v.Id, v.Code, v.MinOrderAmount, v.DiscountAmount, v.DiscountPercentage, v.MaxDiscountAmount, v.Expiration); err != nil {
+		return fmt.Errorf("failed to upsert voucher %s: %w", v.Code, err)
+	}
+	return nil
+}
+
+// GetAll returns every voucher row, expired or not.
+func (s *PostgresVoucherStore) GetAll(ctx context.Context) ([]Voucher, error) {
+	const query = `
+		SELECT id, code, min_order_amount, discount_amount, discount_percentage, max_discount_amount, expiration
+		FROM vouchers
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vouchers: %w", err)
+	}
+	defer rows.Close()
+
+	var vouchers []Voucher
+	for rows.Next() {
+		var v Voucher
+		if err := rows.Scan(&v.Id, &v.Code, &v.MinOrderAmount, &v.DiscountAmount, &v.DiscountPercentage, &v.MaxDiscountAmount, &v.Expiration); err != nil {
+			return nil, fmt.Errorf("failed to scan voucher: %w", err)
+		}
+		vouchers = append(vouchers, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return vouchers, nil
+}
+
+// NeedRefresh reports whether the voucher identified by code is missing, or
+// expires within expirationBuffer of now.
+func (s *PostgresVoucherStore) NeedRefresh(ctx context.Context, code string, expirationBuffer time.Duration) (bool, error) {
+	const query = `SELECT expiration FROM vouchers WHERE code = $1`
+
+	var expiration sql.NullTime
+	err := s.db.QueryRowContext(ctx, query, code).Scan(&expiration)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up voucher %s: %w", code, err)
+	}
+	if !expiration.Valid {
+		return false, nil
+	}
+
+	return expiration.Time.Before(time.Now().Add(expirationBuffer)), nil
+}